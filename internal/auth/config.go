@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config bundles the auth middleware pieces built by FromEnv.
+type Config struct {
+	Store   *Store
+	Limiter *Limiter
+	Quotas  *QuotaTracker
+}
+
+// FromEnv builds auth from environment variables:
+//
+//	TOKENS_FILE                     path to a static bearer-token file (required)
+//	AUTH_HMAC_SECRET                optional secret enabling short-lived issued tokens
+//	RATE_LIMIT_PER_SECOND           requests/sec per token (default 2)
+//	RATE_LIMIT_BURST                burst size per token (default 5)
+//	QUOTA_MAX_CONCURRENT_TASKS      max running tasks per owner (default 3)
+//	QUOTA_MAX_BYTES_PER_DAY         max archived bytes per owner per day (default unlimited)
+//	QUOTA_MAX_ARCHIVES_PER_DAY      max archives created per owner per day (default unlimited)
+//
+// TOKENS_FILE must be set; if it is empty, FromEnv returns an error
+// since unauthenticated deployments should not opt into this package.
+func FromEnv() (*Config, error) {
+	path := os.Getenv("TOKENS_FILE")
+	if path == "" {
+		return nil, fmt.Errorf("auth: TOKENS_FILE is required")
+	}
+	store, err := LoadStatic(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: loading TOKENS_FILE: %w", err)
+	}
+	if secret := os.Getenv("AUTH_HMAC_SECRET"); secret != "" {
+		store = store.WithHMACSecret([]byte(secret))
+	}
+
+	rate := 2.0
+	if v := os.Getenv("RATE_LIMIT_PER_SECOND"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			rate = f
+		}
+	}
+	burst := 5
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			burst = n
+		}
+	}
+
+	maxConcurrent := 3
+	if v := os.Getenv("QUOTA_MAX_CONCURRENT_TASKS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			maxConcurrent = n
+		}
+	}
+	var maxBytes int64
+	if v := os.Getenv("QUOTA_MAX_BYTES_PER_DAY"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			maxBytes = n
+		}
+	}
+	var maxArchives int
+	if v := os.Getenv("QUOTA_MAX_ARCHIVES_PER_DAY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			maxArchives = n
+		}
+	}
+
+	return &Config{
+		Store:   store,
+		Limiter: NewLimiter(rate, burst),
+		Quotas:  NewQuotaTracker(maxConcurrent, maxBytes, maxArchives, 24*time.Hour),
+	}, nil
+}
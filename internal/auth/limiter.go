@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// bucketIdleTTL is how long a key's bucket may sit unused before the
+// eviction loop reclaims it. Keys churn over time (subjects come and
+// go, anonymous callers are keyed by IP), so without eviction buckets
+// for callers who've since left would never be reclaimed.
+const bucketIdleTTL = 10 * time.Minute
+
+// Limiter is a per-key token-bucket rate limiter, one bucket per caller
+// identity (typically a bearer token).
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	rate    float64 // tokens per second
+	burst   float64
+	stop    chan struct{}
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter allowing burst requests immediately and
+// refilling at rate requests per second thereafter. Buckets idle for
+// longer than bucketIdleTTL are reclaimed by a background goroutine;
+// call Close to stop it.
+func NewLimiter(rate float64, burst int) *Limiter {
+	l := &Limiter{
+		buckets: make(map[string]*bucket),
+		rate:    rate,
+		burst:   float64(burst),
+		stop:    make(chan struct{}),
+	}
+	go l.evictLoop()
+	return l
+}
+
+// Close stops the background eviction loop.
+func (l *Limiter) Close() {
+	close(l.stop)
+}
+
+func (l *Limiter) evictLoop() {
+	ticker := time.NewTicker(bucketIdleTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.evictIdle()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+func (l *Limiter) evictIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cutoff := time.Now().Add(-bucketIdleTTL)
+	for key, b := range l.buckets {
+		if b.last.Before(cutoff) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Allow reports whether key may make a request now, consuming a token
+// if so. If denied, it also returns how long the caller should wait
+// before retrying.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens += elapsed * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
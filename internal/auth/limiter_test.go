@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllowsBurstThenThrottles(t *testing.T) {
+	l := NewLimiter(1, 2)
+	defer l.Close()
+
+	if ok, _ := l.Allow("tok"); !ok {
+		t.Fatal("first request should be allowed (burst)")
+	}
+	if ok, _ := l.Allow("tok"); !ok {
+		t.Fatal("second request should be allowed (burst)")
+	}
+	if ok, wait := l.Allow("tok"); ok || wait <= 0 {
+		t.Fatalf("third request should be throttled with a positive wait, got ok=%v wait=%v", ok, wait)
+	}
+}
+
+func TestLimiterEvictsIdleBuckets(t *testing.T) {
+	l := NewLimiter(1, 1)
+	defer l.Close()
+
+	l.Allow("tok")
+	l.mu.Lock()
+	l.buckets["tok"].last = time.Now().Add(-2 * bucketIdleTTL)
+	l.mu.Unlock()
+
+	l.evictIdle()
+
+	l.mu.Lock()
+	_, exists := l.buckets["tok"]
+	l.mu.Unlock()
+	if exists {
+		t.Error("evictIdle should have removed the stale bucket")
+	}
+}
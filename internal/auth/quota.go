@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// usageIdleTTL is how long an owner's usage entry may sit untouched
+// before the eviction loop reclaims it, the same idle-reclaim treatment
+// Limiter gives its buckets: owners come and go, so without it the
+// usage map would grow forever.
+const usageIdleTTL = 10 * time.Minute
+
+// QuotaTracker enforces per-owner resource limits and implements
+// task.Quotas. It caps concurrent running tasks and both the number and
+// total size of archives created within a per-owner window of length
+// period, resetting those counters once the window elapses (a fixed
+// window, not a sliding one).
+type QuotaTracker struct {
+	mu                sync.Mutex
+	maxConcurrent     int
+	maxBytesPeriod    int64
+	maxArchivesPeriod int
+	period            time.Duration
+	usage             map[string]*ownerUsage
+	stop              chan struct{}
+}
+
+type ownerUsage struct {
+	running      int
+	bytesUsed    int64
+	archivesUsed int
+	periodStart  time.Time
+	lastTouched  time.Time
+}
+
+// NewQuotaTracker limits each owner to maxConcurrent running tasks and,
+// per period (e.g. per day), maxBytesPerPeriod archived bytes and
+// maxArchivesPerPeriod archives. Zero disables the corresponding check.
+// Owner entries idle for longer than usageIdleTTL are reclaimed by a
+// background goroutine; call Close to stop it.
+func NewQuotaTracker(maxConcurrent int, maxBytesPerPeriod int64, maxArchivesPerPeriod int, period time.Duration) *QuotaTracker {
+	q := &QuotaTracker{
+		maxConcurrent:     maxConcurrent,
+		maxBytesPeriod:    maxBytesPerPeriod,
+		maxArchivesPeriod: maxArchivesPerPeriod,
+		period:            period,
+		usage:             make(map[string]*ownerUsage),
+		stop:              make(chan struct{}),
+	}
+	go q.evictLoop()
+	return q
+}
+
+// Close stops the background eviction loop.
+func (q *QuotaTracker) Close() {
+	close(q.stop)
+}
+
+func (q *QuotaTracker) evictLoop() {
+	ticker := time.NewTicker(usageIdleTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.evictIdle()
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+func (q *QuotaTracker) evictIdle() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cutoff := time.Now().Add(-usageIdleTTL)
+	for owner, u := range q.usage {
+		if u.running == 0 && u.lastTouched.Before(cutoff) {
+			delete(q.usage, owner)
+		}
+	}
+}
+
+// AllowCreate reports whether owner is under its concurrent-task limit,
+// reserving a slot if so.
+func (q *QuotaTracker) AllowCreate(owner string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.ownerLocked(owner)
+	if q.maxConcurrent > 0 && u.running >= q.maxConcurrent {
+		return false
+	}
+	u.running++
+	return true
+}
+
+// ReleaseCreate frees the concurrent-task slot reserved by AllowCreate.
+func (q *QuotaTracker) ReleaseCreate(owner string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.ownerLocked(owner)
+	if u.running > 0 {
+		u.running--
+	}
+}
+
+// AllowArchive reports whether owner may create one more archive of
+// bytes size within the current period, counting both the archive and
+// its bytes against their quota if so.
+func (q *QuotaTracker) AllowArchive(owner string, bytes int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.ownerLocked(owner)
+	if q.maxBytesPeriod > 0 && u.bytesUsed+bytes > q.maxBytesPeriod {
+		return false
+	}
+	if q.maxArchivesPeriod > 0 && u.archivesUsed+1 > q.maxArchivesPeriod {
+		return false
+	}
+	u.bytesUsed += bytes
+	u.archivesUsed++
+	return true
+}
+
+func (q *QuotaTracker) ownerLocked(owner string) *ownerUsage {
+	u, ok := q.usage[owner]
+	now := time.Now()
+	if !ok {
+		u = &ownerUsage{periodStart: now}
+		q.usage[owner] = u
+	}
+	if q.period > 0 && now.Sub(u.periodStart) >= q.period {
+		u.bytesUsed = 0
+		u.archivesUsed = 0
+		u.periodStart = now
+	}
+	u.lastTouched = now
+	return u
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuotaTrackerConcurrentTasks(t *testing.T) {
+	q := NewQuotaTracker(2, 0, 0, time.Hour)
+	defer q.Close()
+	if !q.AllowCreate("a") || !q.AllowCreate("a") {
+		t.Fatal("first two concurrent tasks should be allowed")
+	}
+	if q.AllowCreate("a") {
+		t.Fatal("third concurrent task should be denied")
+	}
+	q.ReleaseCreate("a")
+	if !q.AllowCreate("a") {
+		t.Error("releasing a slot should allow another task")
+	}
+}
+
+func TestQuotaTrackerBytesAndArchivesPerPeriod(t *testing.T) {
+	q := NewQuotaTracker(0, 100, 2, time.Hour)
+	defer q.Close()
+
+	if !q.AllowArchive("a", 60) {
+		t.Fatal("first archive within byte/count budget should be allowed")
+	}
+	if q.AllowArchive("a", 60) {
+		t.Error("second archive should be denied: would exceed the byte budget")
+	}
+
+	q2 := NewQuotaTracker(0, 0, 2, time.Hour)
+	defer q2.Close()
+	if !q2.AllowArchive("b", 1) || !q2.AllowArchive("b", 1) {
+		t.Fatal("first two archives within the count budget should be allowed")
+	}
+	if q2.AllowArchive("b", 1) {
+		t.Error("third archive should be denied: would exceed the per-day archive count")
+	}
+}
+
+func TestQuotaTrackerResetsAfterPeriod(t *testing.T) {
+	q := NewQuotaTracker(0, 10, 1, time.Hour)
+	defer q.Close()
+	if !q.AllowArchive("a", 10) {
+		t.Fatal("first archive should be allowed")
+	}
+	if q.AllowArchive("a", 1) {
+		t.Fatal("second archive should be denied before the period elapses")
+	}
+
+	q.mu.Lock()
+	q.usage["a"].periodStart = time.Now().Add(-2 * time.Hour)
+	q.mu.Unlock()
+
+	if !q.AllowArchive("a", 10) {
+		t.Error("archive should be allowed again once the period has rolled over")
+	}
+}
+
+func TestQuotaTrackerEvictsIdleOwners(t *testing.T) {
+	q := NewQuotaTracker(0, 0, 1, time.Hour)
+	defer q.Close()
+
+	q.AllowArchive("a", 1)
+	q.mu.Lock()
+	q.usage["a"].lastTouched = time.Now().Add(-2 * usageIdleTTL)
+	q.mu.Unlock()
+
+	q.evictIdle()
+
+	q.mu.Lock()
+	_, exists := q.usage["a"]
+	q.mu.Unlock()
+	if exists {
+		t.Error("evictIdle should have removed the stale owner")
+	}
+}
+
+func TestQuotaTrackerDoesNotEvictRunningOwner(t *testing.T) {
+	q := NewQuotaTracker(1, 0, 0, time.Hour)
+	defer q.Close()
+
+	q.AllowCreate("a")
+	q.mu.Lock()
+	q.usage["a"].lastTouched = time.Now().Add(-2 * usageIdleTTL)
+	q.mu.Unlock()
+
+	q.evictIdle()
+
+	q.mu.Lock()
+	_, exists := q.usage["a"]
+	q.mu.Unlock()
+	if !exists {
+		t.Error("evictIdle should not remove an owner with a concurrent task still running")
+	}
+}
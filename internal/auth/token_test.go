@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssueReturnsStableSubjectAcrossTokens(t *testing.T) {
+	s := (&Store{static: map[string]bool{}}).WithHMACSecret([]byte("secret"))
+
+	tok1, err := s.Issue("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	tok2, err := s.Issue("alice", 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if tok1 == tok2 {
+		t.Fatal("two Issue calls with different ttls minted the same token")
+	}
+
+	who1, err := s.Authenticate(tok1)
+	if err != nil {
+		t.Fatalf("Authenticate(tok1): %v", err)
+	}
+	who2, err := s.Authenticate(tok2)
+	if err != nil {
+		t.Fatalf("Authenticate(tok2): %v", err)
+	}
+	if who1 != "alice" || who2 != "alice" {
+		t.Errorf("Authenticate returned %q and %q, want \"alice\" for both: a fresh Issue must not mint a new quota/rate-limit identity", who1, who2)
+	}
+}
+
+func TestAuthenticateRejectsExpiredOrTamperedToken(t *testing.T) {
+	s := (&Store{static: map[string]bool{}}).WithHMACSecret([]byte("secret"))
+
+	expired, err := s.Issue("alice", -time.Second)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := s.Authenticate(expired); err != ErrInvalidToken {
+		t.Errorf("Authenticate(expired) = %v, want ErrInvalidToken", err)
+	}
+
+	tok, err := s.Issue("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := s.Authenticate(tok + "x"); err != ErrInvalidToken {
+		t.Errorf("Authenticate(tampered) = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestIssueRejectsSubjectWithDot(t *testing.T) {
+	s := (&Store{static: map[string]bool{}}).WithHMACSecret([]byte("secret"))
+	if _, err := s.Issue("ali.ce", time.Hour); err == nil {
+		t.Error("Issue should reject a subject containing '.', since it would be ambiguous with the field separator")
+	}
+}
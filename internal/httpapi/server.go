@@ -1,43 +1,171 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/LiHiy93/workmate-zipper/internal/auth"
 	"github.com/LiHiy93/workmate-zipper/internal/task"
 )
 
+// defaultAnonymousRate and defaultAnonymousBurst mirror auth.Config's own
+// defaults, used to rate-limit by IP when no auth.Config (and therefore
+// no per-token Limiter) is configured.
+const (
+	defaultAnonymousRate  = 2.0
+	defaultAnonymousBurst = 5
+)
+
 type Server struct {
 	mux     *http.ServeMux
 	manager *task.Manager
+	auth    *auth.Config
+	limiter *auth.Limiter
 }
 
-func NewServer(m *task.Manager) http.Handler {
-	s := &Server{mux: http.NewServeMux(), manager: m}
+// NewServer builds the HTTP API. authCfg may be nil, in which case
+// every request is treated as anonymous: no bearer token is required
+// and tasks have no owner, but requests are still rate limited by
+// remote IP.
+func NewServer(m *task.Manager, authCfg *auth.Config) http.Handler {
+	s := &Server{mux: http.NewServeMux(), manager: m, auth: authCfg}
+	if authCfg != nil {
+		m.SetQuotas(authCfg.Quotas)
+		s.limiter = authCfg.Limiter
+	} else {
+		s.limiter = auth.NewLimiter(defaultAnonymousRate, defaultAnonymousBurst)
+	}
 	s.routes()
 	return s.mux
 }
 
 func (s *Server) routes() {
-	s.mux.HandleFunc("POST /tasks", s.handleCreateTask)
-	s.mux.HandleFunc("POST /tasks/{id}/items", s.handleAddItem)
-	s.mux.HandleFunc("POST /tasks/{id}/run", s.handleRun)
-	s.mux.HandleFunc("GET /tasks/{id}/status", s.handleStatus)
-	s.mux.HandleFunc("GET /tasks/{id}/result", s.handleResult)
-	files := http.StripPrefix("/files/", http.FileServer(http.Dir("results")))
-	s.mux.Handle("/files/", files)
+	s.mux.HandleFunc("POST /tasks", s.withAuth(s.rateLimited(s.handleCreateTask)))
+	s.mux.HandleFunc("POST /tasks/{id}/items", s.withAuth(s.rateLimited(s.handleAddItem)))
+	s.mux.HandleFunc("POST /tasks/{id}/run", s.withAuth(s.rateLimited(s.handleRun)))
+	s.mux.HandleFunc("GET /tasks/{id}/status", s.withAuth(s.handleStatus))
+	s.mux.HandleFunc("GET /tasks/{id}/result", s.withAuth(s.handleResult))
+	s.mux.HandleFunc("GET /tasks/{id}/events", s.withAuth(s.handleEvents))
+	s.mux.Handle("/files/", http.StripPrefix("/files/", http.HandlerFunc(s.handleFiles)))
 	s.mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
 }
 
+type ctxKey int
+
+// ownerKey is the context key under which withAuth stores the
+// authenticated caller's identity.
+const ownerKey ctxKey = iota
+
+// owner returns the authenticated caller identity for r, or "" if auth
+// is disabled.
+func owner(r *http.Request) string {
+	v, _ := r.Context().Value(ownerKey).(string)
+	return v
+}
+
+// withAuth rejects requests with a missing or invalid bearer token when
+// auth is configured; otherwise it passes requests through unchanged
+// with no owner set.
+func (s *Server) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	if s.auth == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		tok := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		who, err := s.auth.Store.Authenticate(tok)
+		if err != nil {
+			httpError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		ctx := context.WithValue(r.Context(), ownerKey, who)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// rateLimited applies s.limiter to next, keyed by the authenticated
+// caller's identity when there is one (the static token, or the subject
+// of an issued token), falling back to their remote IP
+// otherwise (anonymous deployments, or any request that reaches this
+// point without an owner set).
+func (s *Server) rateLimited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := owner(r)
+		if key == "" {
+			key = clientIP(r)
+		}
+		allowed, wait := s.limiter.Allow(key)
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+1)))
+			httpError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientIP extracts the caller's address from RemoteAddr, stripping the
+// port; it falls back to the raw value if RemoteAddr isn't host:port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+type createTaskReq struct {
+	Format         string `json:"format"`
+	CallbackURL    string `json:"callback_url"`
+	CallbackSecret string `json:"callback_secret"`
+}
+
 func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
-	t := s.manager.Create()
-	writeJSON(w, http.StatusCreated, map[string]string{"id": t.ID})
+	var in createTaskReq
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil && err != io.EOF {
+			httpError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+	t, err := s.manager.Create(task.CreateOptions{
+		Owner:          owner(r),
+		Format:         in.Format,
+		CallbackURL:    strings.TrimSpace(in.CallbackURL),
+		CallbackSecret: in.CallbackSecret,
+	})
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"id": t.ID, "format": string(t.Format)})
+}
+
+// handleFiles serves archives from results/, setting the Content-Type
+// for formats the stdlib mime table doesn't know about (tar/tar.gz/tar.zst).
+// It refuses dotfile paths so internal state (e.g. the task store, were
+// it ever placed under results/) can never be served alongside archives.
+func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
+	for _, seg := range strings.Split(r.URL.Path, "/") {
+		if strings.HasPrefix(seg, ".") && seg != "" {
+			httpError(w, http.StatusNotFound, "not found")
+			return
+		}
+	}
+	if ct := task.ContentTypeForExt(r.URL.Path); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	http.FileServer(http.Dir("results")).ServeHTTP(w, r)
 }
 
 type addItemReq struct {
@@ -46,6 +174,9 @@ type addItemReq struct {
 
 func (s *Server) handleAddItem(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	if !s.authorizeTask(w, r, id) {
+		return
+	}
 	var in addItemReq
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		httpError(w, http.StatusBadRequest, "invalid json")
@@ -77,6 +208,9 @@ func (s *Server) handleAddItem(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	if !s.authorizeTask(w, r, id) {
+		return
+	}
 	err := s.manager.Run(id, r.Context())
 	if err != nil {
 		switch {
@@ -88,6 +222,8 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 			httpError(w, http.StatusBadRequest, "no valid items to process")
 		case errors.Is(err, task.ErrAlreadyStarted):
 			httpError(w, http.StatusConflict, "task already started")
+		case errors.Is(err, task.ErrQuotaExceeded):
+			httpError(w, http.StatusTooManyRequests, "quota exceeded")
 		default:
 			httpError(w, http.StatusBadRequest, err.Error())
 		}
@@ -96,29 +232,120 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusAccepted, map[string]string{"status": "accepted"})
 }
 
+// authorizeTask reports whether the caller may act on id, writing a 404
+// (not a 403, to avoid leaking existence of other callers' tasks) and
+// returning false if the task is missing or owned by someone else.
+func (s *Server) authorizeTask(w http.ResponseWriter, r *http.Request, id string) bool {
+	st := s.manager.Status(id)
+	if st == nil || (st.Owner != "" && st.Owner != owner(r)) {
+		httpError(w, http.StatusNotFound, "task not found")
+		return false
+	}
+	return true
+}
+
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	if !s.authorizeTask(w, r, id) {
+		return
+	}
 	st := s.manager.Status(id)
 	if st == nil {
 		httpError(w, http.StatusNotFound, "task not found")
 		return
 	}
-	resp := map[string]any{"status": st.Status, "added": st.Added, "done": st.Done, "error": st.Error, "result_url": ""}
-	if st.ResultPath != "" {
-		filename := filepath.Base(st.ResultPath)
-		resp["result_url"] = "/files/" + filename
+	items := make([]map[string]any, len(st.Items))
+	for i, it := range st.Items {
+		items[i] = map[string]any{
+			"url":              it.URL,
+			"state":            it.State,
+			"bytes_downloaded": it.BytesDownloaded,
+			"bytes_total":      it.BytesTotal,
+			"error":            it.Error,
+		}
+	}
+	resultURL := st.ResultURL
+	if fresh, err := s.manager.ResultURL(id); err == nil {
+		resultURL = fresh
+	}
+	resp := map[string]any{"status": st.Status, "added": st.Added, "done": st.Done, "error": st.Error, "format": st.Format, "result_url": resultURL, "items": items}
+	if st.Callback.URL != "" {
+		resp["callback"] = map[string]any{
+			"delivered": st.Callback.Delivered,
+			"attempts":  st.Callback.Attempts,
+		}
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	if !s.authorizeTask(w, r, id) {
+		return
+	}
 	st := s.manager.Status(id)
-	if st == nil || st.ResultPath == "" {
+	if st == nil || st.ResultKey == "" {
 		httpError(w, http.StatusNotFound, "result not ready")
 		return
 	}
-	http.ServeFile(w, r, st.ResultPath)
+	resultURL, err := s.manager.ResultURL(id)
+	if err != nil {
+		httpError(w, http.StatusNotFound, "result not ready")
+		return
+	}
+	s.manager.RecordDownload(id)
+	if strings.HasPrefix(resultURL, "http://") || strings.HasPrefix(resultURL, "https://") {
+		http.Redirect(w, r, resultURL, http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", st.Format.ContentType())
+	http.ServeFile(w, r, filepath.Join("results", st.ResultKey))
+}
+
+// handleEvents streams task state transitions and per-item progress as
+// Server-Sent Events, so clients don't need to poll /status.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.authorizeTask(w, r, id) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	lastSeen, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	events, cancel := s.manager.Subscribe(id)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if e.Seq <= lastSeen {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
 }
 
 func writeJSON(w http.ResponseWriter, code int, v any) {
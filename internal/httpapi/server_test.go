@@ -0,0 +1,38 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LiHiy93/workmate-zipper/internal/storage"
+	"github.com/LiHiy93/workmate-zipper/internal/task"
+)
+
+func TestAnonymousRequestsAreRateLimitedByIP(t *testing.T) {
+	m := task.NewManagerWithStore(1, task.NewMemoryStore(), storage.NewLocal(t.TempDir(), "/files"), time.Hour, 0)
+	defer m.Close()
+	h := NewServer(m, nil)
+
+	do := func(ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < defaultAnonymousBurst; i++ {
+		if code := do("10.0.0.1"); code != http.StatusCreated {
+			t.Fatalf("request %d from 10.0.0.1: got %d, want %d", i, code, http.StatusCreated)
+		}
+	}
+	if code := do("10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Errorf("request past burst from 10.0.0.1: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// A different caller IP has its own bucket and isn't affected by the first's burst.
+	if code := do("10.0.0.2"); code != http.StatusCreated {
+		t.Errorf("first request from 10.0.0.2: got %d, want %d", code, http.StatusCreated)
+	}
+}
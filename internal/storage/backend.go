@@ -0,0 +1,31 @@
+// Package storage abstracts where result archives live, so Manager can
+// write to the local filesystem or an S3-compatible bucket interchangeably.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotExist is returned by Get/Stat when key has no object.
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Backend stores and serves task result archives.
+type Backend interface {
+	// Put writes r under key and returns a URL clients can use to fetch
+	// it (a local /files/ path, or a presigned URL for remote backends).
+	Put(ctx context.Context, key string, r io.Reader) (url string, err error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// Stat reports the size of key, or ErrNotExist if it doesn't exist.
+	Stat(ctx context.Context, key string) (size int64, err error)
+	// URL returns a URL clients can use to fetch key right now. Callers
+	// should call this per request rather than caching the result: for
+	// remote backends it may be a freshly minted, short-lived link.
+	URL(ctx context.Context, key string) (string, error)
+	// List returns the keys of every object currently stored, used to
+	// re-register archives the task store doesn't know about (e.g. after
+	// a restart).
+	List(ctx context.Context) ([]string, error)
+}
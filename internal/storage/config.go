@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// FromEnv selects a Backend at startup: STORAGE_KIND=s3 configures an
+// S3-compatible backend from S3_* env vars, anything else (including
+// unset) keeps archives on the local filesystem under localDir, served
+// under localBaseURL.
+func FromEnv(localDir, localBaseURL string) (Backend, error) {
+	if os.Getenv("STORAGE_KIND") != "s3" {
+		return NewLocal(localDir, localBaseURL), nil
+	}
+
+	expiry := time.Hour
+	if v := os.Getenv("S3_PRESIGN_EXPIRY_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			expiry = time.Duration(secs) * time.Second
+		}
+	}
+	return NewS3(S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		UseSSL:          os.Getenv("S3_USE_SSL") != "false",
+		PresignExpiry:   expiry,
+	})
+}
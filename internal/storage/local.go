@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores archives as plain files under Dir, the current behavior
+// before pluggable backends. BaseURL (e.g. "/files") is prefixed to a
+// key to build the URL returned from Put.
+type Local struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewLocal(dir, baseURL string) *Local {
+	_ = os.MkdirAll(dir, 0o755)
+	return &Local{Dir: dir, BaseURL: baseURL}
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.Dir, key)
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	tmp := l.path(key) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, l.path(key)); err != nil {
+		return "", err
+	}
+	return l.BaseURL + "/" + key, nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(l.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	err := os.Remove(l.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (int64, error) {
+	fi, err := os.Stat(l.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNotExist
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// URL returns key's stable /files/ path; local archives don't expire.
+func (l *Local) URL(ctx context.Context, key string) (string, error) {
+	return l.BaseURL + "/" + key, nil
+}
+
+// List returns the name of every archive under Dir, used to re-register
+// archives the task store doesn't know about after a restart.
+func (l *Local) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(l.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), ".tmp") {
+			continue
+		}
+		keys = append(keys, e.Name())
+	}
+	return keys, nil
+}
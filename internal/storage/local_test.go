@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestLocalPutGetStatDelete(t *testing.T) {
+	l := NewLocal(t.TempDir(), "/files")
+	ctx := context.Background()
+
+	url, err := l.Put(ctx, "a.zip", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if url != "/files/a.zip" {
+		t.Errorf("Put url = %q, want /files/a.zip", url)
+	}
+
+	if size, err := l.Stat(ctx, "a.zip"); err != nil || size != 4 {
+		t.Errorf("Stat = %d, %v, want 4, nil", size, err)
+	}
+
+	keys, err := l.List(ctx)
+	if err != nil || len(keys) != 1 || keys[0] != "a.zip" {
+		t.Errorf("List = %v, %v, want [a.zip]", keys, err)
+	}
+
+	if err := l.Delete(ctx, "a.zip"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := l.Stat(ctx, "a.zip"); err != ErrNotExist {
+		t.Errorf("Stat after Delete = %v, want ErrNotExist", err)
+	}
+}
+
+func TestLocalListSkipsTmpFiles(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLocal(dir, "/files")
+	ctx := context.Background()
+
+	if _, err := l.Put(ctx, "a.zip", bytes.NewReader([]byte("data"))); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate an in-progress upload's .tmp file sitting alongside it.
+	if _, err := l.Put(ctx, "b.zip.tmp", bytes.NewReader([]byte("partial"))); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := l.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if k == "b.zip.tmp" {
+			t.Errorf("List returned an in-progress .tmp file: %v", keys)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 stores archives in an S3-compatible bucket (AWS S3, MinIO, ...) and
+// serves them via presigned GET URLs instead of proxying bytes through
+// this process.
+type S3 struct {
+	client        *minio.Client
+	bucket        string
+	presignExpiry time.Duration
+}
+
+// S3Config configures an S3 backend; Endpoint/Bucket/credentials are
+// typically sourced from env vars via FromEnv.
+type S3Config struct {
+	Endpoint        string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+	PresignExpiry   time.Duration
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	expiry := cfg.PresignExpiry
+	if expiry <= 0 {
+		expiry = time.Hour
+	}
+	return &S3{client: client, bucket: cfg.Bucket, presignExpiry: expiry}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	if _, err := s.client.PutObject(ctx, s.bucket, key, r, -1, minio.PutObjectOptions{}); err != nil {
+		return "", err
+	}
+	return s.URL(ctx, key)
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.Stat(); err != nil {
+		return nil, ErrNotExist
+	}
+	return obj, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (int64, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return 0, ErrNotExist
+	}
+	return info.Size, nil
+}
+
+// URL mints a fresh presigned GET URL for key, valid for presignExpiry
+// from now. Callers should call this per request rather than caching
+// the result, since it's decoupled from (and typically shorter than)
+// the task's own TTL.
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, s.presignExpiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// List returns the key of every object in the bucket, used to
+// re-register archives the task store doesn't know about after a
+// restart.
+func (s *S3) List(ctx context.Context) ([]string, error) {
+	var keys []string
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
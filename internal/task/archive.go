@@ -0,0 +1,250 @@
+package task
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format selects the archive container used to bundle a task's downloads.
+type Format string
+
+const (
+	FormatZip    Format = "zip"
+	FormatTar    Format = "tar"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarZst Format = "tar.zst"
+)
+
+// DefaultFormat is used when a task doesn't specify one.
+const DefaultFormat = FormatZip
+
+// ParseFormat validates a caller-supplied format string, defaulting empty
+// input to DefaultFormat.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "":
+		return DefaultFormat, nil
+	case FormatZip, FormatTar, FormatTarGz, FormatTarZst:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unsupported format %q", s)
+	}
+}
+
+// Ext is the file extension written to disk and served under /files/.
+func (f Format) Ext() string {
+	switch f {
+	case FormatTar:
+		return ".tar"
+	case FormatTarGz:
+		return ".tar.gz"
+	case FormatTarZst:
+		return ".tar.zst"
+	default:
+		return ".zip"
+	}
+}
+
+// ContentType is what handleResult and /files/ should serve.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatTar:
+		return "application/x-tar"
+	case FormatTarGz:
+		return "application/gzip"
+	case FormatTarZst:
+		return "application/zstd"
+	default:
+		return "application/zip"
+	}
+}
+
+// ContentTypeForExt returns the MIME type for a file served from results/,
+// covering the archive formats above by their (possibly multi-part) extension.
+func ContentTypeForExt(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, FormatTarGz.Ext()):
+		return FormatTarGz.ContentType()
+	case strings.HasSuffix(lower, FormatTarZst.Ext()):
+		return FormatTarZst.ContentType()
+	case strings.HasSuffix(lower, FormatTar.Ext()):
+		return FormatTar.ContentType()
+	case strings.HasSuffix(lower, FormatZip.Ext()):
+		return FormatZip.ContentType()
+	default:
+		return ""
+	}
+}
+
+// archiveIDFromFilename recovers the task ID and format encoded in a
+// results/ filename (e.g. "abc123.tar.gz" -> FormatTarGz, "abc123"), used
+// when resuming to re-register archives the store doesn't know about.
+func archiveIDFromFilename(name string) (Format, string) {
+	for _, f := range []Format{FormatTarGz, FormatTarZst, FormatTar, FormatZip} {
+		if strings.HasSuffix(name, f.Ext()) {
+			return f, strings.TrimSuffix(name, f.Ext())
+		}
+	}
+	return "", ""
+}
+
+// Archiver bundles a set of files into a single archive at out.
+type Archiver interface {
+	Archive(out string, files []string) error
+}
+
+// NewArchiver returns the Archiver for f.
+func NewArchiver(f Format) (Archiver, error) {
+	switch f {
+	case FormatZip:
+		return zipArchiver{}, nil
+	case FormatTar:
+		return tarArchiver{}, nil
+	case FormatTarGz:
+		return tarGzArchiver{}, nil
+	case FormatTarZst:
+		return tarZstArchiver{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", f)
+	}
+}
+
+type zipArchiver struct{}
+
+func (zipArchiver) Archive(out string, files []string) error {
+	return zipFiles(out, files)
+}
+
+type tarArchiver struct{}
+
+func (tarArchiver) Archive(out string, files []string) error {
+	return writeTar(out, files, nil)
+}
+
+type tarGzArchiver struct{}
+
+func (tarGzArchiver) Archive(out string, files []string) error {
+	return writeTar(out, files, func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	})
+}
+
+type tarZstArchiver struct{}
+
+func (tarZstArchiver) Archive(out string, files []string) error {
+	return writeTar(out, files, func(w io.Writer) io.WriteCloser {
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			// NewWriter only fails on invalid options, and we set none.
+			panic(err)
+		}
+		return zw
+	})
+}
+
+func zipFiles(out string, files []string) error {
+	tmp := out + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, p := range files {
+		if err := addZipFile(zw, p); err != nil {
+			_ = zw.Close()
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	_ = os.Remove(out)
+	return os.Rename(tmp, out)
+}
+
+func addZipFile(zw *zip.Writer, path string) error {
+	w, err := zw.Create(filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = io.Copy(w, fd)
+	return err
+}
+
+// writeTar streams files into a tar archive at out, optionally wrapping
+// the underlying file in a compressor (gzip, zstd); wrap may be nil for
+// a plain, uncompressed tar.
+func writeTar(out string, files []string, wrap func(io.Writer) io.WriteCloser) error {
+	tmp := out + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var comp io.WriteCloser
+	if wrap != nil {
+		comp = wrap(f)
+		w = comp
+	}
+
+	tw := tar.NewWriter(w)
+	for _, p := range files {
+		if err := addTarFile(tw, p); err != nil {
+			_ = tw.Close()
+			if comp != nil {
+				_ = comp.Close()
+			}
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if comp != nil {
+		if err := comp.Close(); err != nil {
+			return err
+		}
+	}
+	_ = os.Remove(out)
+	return os.Rename(tmp, out)
+}
+
+func addTarFile(tw *tar.Writer, path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.Base(path)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = io.Copy(tw, fd)
+	return err
+}
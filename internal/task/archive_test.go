@@ -0,0 +1,60 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiversRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.pdf")
+	if err := os.WriteFile(src, []byte("%PDF-1.4 hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []Format{FormatZip, FormatTar, FormatTarGz, FormatTarZst} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			archiver, err := NewArchiver(f)
+			if err != nil {
+				t.Fatalf("NewArchiver(%q): %v", f, err)
+			}
+			out := filepath.Join(dir, "archive"+f.Ext())
+			if err := archiver.Archive(out, []string{src}); err != nil {
+				t.Fatalf("Archive: %v", err)
+			}
+			if fi, err := os.Stat(out); err != nil || fi.Size() == 0 {
+				t.Fatalf("expected non-empty archive at %s, err=%v", out, err)
+			}
+		})
+	}
+}
+
+func TestParseFormatRejectsUnknown(t *testing.T) {
+	if _, err := ParseFormat("rar"); err == nil {
+		t.Error("ParseFormat(\"rar\") should have failed")
+	}
+	if f, err := ParseFormat(""); err != nil || f != DefaultFormat {
+		t.Errorf("ParseFormat(\"\") = %q, %v, want %q, nil", f, err, DefaultFormat)
+	}
+}
+
+func TestArchiveIDFromFilename(t *testing.T) {
+	cases := map[string]struct {
+		format Format
+		id     string
+	}{
+		"abc.tar.gz":  {FormatTarGz, "abc"},
+		"abc.tar.zst": {FormatTarZst, "abc"},
+		"abc.tar":     {FormatTar, "abc"},
+		"abc.zip":     {FormatZip, "abc"},
+		"abc.tmp":     {"", ""},
+	}
+	for name, want := range cases {
+		format, id := archiveIDFromFilename(name)
+		if format != want.format || id != want.id {
+			t.Errorf("archiveIDFromFilename(%q) = (%q, %q), want (%q, %q)", name, format, id, want.format, want.id)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package task
+
+import "sync"
+
+// EventType identifies what changed about a task or one of its items.
+type EventType string
+
+const (
+	EventStateChanged EventType = "state_changed"
+	EventItemStarted  EventType = "item_started"
+	EventItemProgress EventType = "item_progress"
+	EventItemDone     EventType = "item_done"
+	EventArchiveBuilt EventType = "archive_built"
+)
+
+// Event is a single task state transition or item progress update,
+// broadcast to subscribers registered via Manager.Subscribe.
+type Event struct {
+	Seq    int64     `json:"seq"`
+	Type   EventType `json:"type"`
+	Status Status    `json:"status"`
+	Added  int       `json:"added"`
+	Done   int       `json:"done"`
+	Error  string    `json:"error,omitempty"`
+	Item   *Item     `json:"item,omitempty"`
+}
+
+// broker fans out one task's events to any number of subscribers using
+// small buffered channels with drop-oldest semantics, so a slow SSE
+// client can't stall processTask.
+type broker struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+	last Event
+	seq  int64
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[chan Event]struct{})}
+}
+
+func (b *broker) publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	e.Seq = b.seq
+	b.last = e
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
+
+// subscribe registers a new subscriber and returns the most recently
+// published event (zero Seq if none yet) so the caller can replay it.
+func (b *broker) subscribe() (chan Event, Event, func()) {
+	ch := make(chan Event, 16)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	last := b.last
+	b.mu.Unlock()
+	cancel := func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+	return ch, last, cancel
+}
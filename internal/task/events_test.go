@@ -0,0 +1,65 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBrokerDropsOldestWhenSubscriberIsSlow(t *testing.T) {
+	b := newBroker()
+	ch, _, cancel := b.subscribe()
+	defer cancel()
+
+	// The subscriber's buffer is 16; publish well past that without
+	// draining so publish must drop older events instead of blocking.
+	const n = 32
+	for i := 0; i < n; i++ {
+		b.publish(Event{Type: EventItemProgress})
+	}
+
+	var last Event
+	for {
+		select {
+		case e := <-ch:
+			last = e
+			continue
+		default:
+		}
+		break
+	}
+	if last.Seq != n {
+		t.Errorf("last buffered event Seq = %d, want %d (newest should survive)", last.Seq, n)
+	}
+}
+
+func TestBrokerSubscribeReplaysLastEvent(t *testing.T) {
+	b := newBroker()
+	b.publish(Event{Type: EventStateChanged, Status: StatusRunning})
+
+	ch, last, cancel := b.subscribe()
+	defer cancel()
+	if last.Status != StatusRunning {
+		t.Fatalf("subscribe() last = %+v, want Status running", last)
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("subscribe should not also push the replayed event onto the channel, got %+v", e)
+	default:
+	}
+}
+
+func TestEventJSONUsesSnakeCase(t *testing.T) {
+	data, err := json.Marshal(Event{Seq: 1, Type: EventStateChanged, Status: StatusDone, Added: 2, Done: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"seq", "type", "status", "added", "done"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("marshaled event missing snake_case key %q: %s", key, data)
+		}
+	}
+}
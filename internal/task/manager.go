@@ -1,7 +1,6 @@
 package task
 
 import (
-	"archive/zip"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -15,6 +14,10 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/LiHiy93/workmate-zipper/internal/storage"
 )
 
 var (
@@ -24,8 +27,22 @@ var (
 	ErrBusy            = errors.New("too many tasks running")
 	ErrNoItems         = errors.New("no items")
 	ErrUnsupportedType = errors.New("unsupported type")
+	ErrQuotaExceeded   = errors.New("quota exceeded")
 )
 
+// Quotas gates per-owner resource usage; auth.QuotaTracker implements it
+// and httpapi.NewServer wires it in via Manager.SetQuotas.
+// A nil Quotas means no quota enforcement.
+type Quotas interface {
+	// AllowCreate reports whether owner may start another concurrent
+	// task, reserving a slot if so. Call ReleaseCreate once it ends.
+	AllowCreate(owner string) bool
+	ReleaseCreate(owner string)
+	// AllowArchive reports whether owner may create another archive of
+	// the given size, recording it against their daily usage if so.
+	AllowArchive(owner string, bytes int64) bool
+}
+
 type Status string
 
 const (
@@ -35,48 +52,356 @@ const (
 	StatusError   Status = "error"
 )
 
+// defaultTTL is how long a finished archive is kept before the sweeper
+// reclaims it, mirroring transfer.sh's expiring-upload model.
+const defaultTTL = 24 * time.Hour
+
+// defaultMaxDownloads is how many times a result may be fetched before
+// the sweeper reclaims it, regardless of TTL. Zero means unlimited.
+const defaultMaxDownloads = 0
+
+// defaultDownloadWorkers bounds how many of a task's items are fetched
+// concurrently; it is separate from the task-level parallelism in sem.
+const defaultDownloadWorkers = 3
+
+// maxRedirects bounds redirect chains followed while downloading an item.
+const maxRedirects = 5
+
+// maxDownloadAttempts bounds retries of a single item after a partial
+// failure; retries resume via a Range request instead of starting over.
+const maxDownloadAttempts = 3
+
+// ItemState is the lifecycle of a single download within a task.
+type ItemState string
+
+const (
+	ItemPending     ItemState = "pending"
+	ItemDownloading ItemState = "downloading"
+	ItemDone        ItemState = "done"
+	ItemError       ItemState = "error"
+)
+
+// Item tracks one URL's download progress so clients can render a
+// real progress UI instead of a single task-wide counter.
+type Item struct {
+	URL             string    `json:"url"`
+	State           ItemState `json:"state"`
+	BytesDownloaded int64     `json:"bytes_downloaded"`
+	BytesTotal      int64     `json:"bytes_total"`
+	Error           string    `json:"error,omitempty"`
+}
+
 type Task struct {
-	ID         string
-	Items      []string
-	Started    bool
-	Status     Status
-	Error      string
-	ResultPath string
-	Added      int
-	Done       int
-	mu         sync.Mutex
+	ID           string
+	Owner        string
+	Items        []*Item
+	Format       Format
+	Started      bool
+	Status       Status
+	Error        string
+	ResultKey    string
+	ResultURL    string
+	Added        int
+	Done         int
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	Downloads    int
+	MaxDownloads int
+	Callback     Callback
+	mu           sync.Mutex
+	br           *broker
+}
+
+// ensureBroker lazily creates the task's event broker; tasks loaded from
+// the store (e.g. after a restart) don't have one until first subscribed.
+func (t *Task) ensureBroker() *broker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.br == nil {
+		t.br = newBroker()
+	}
+	return t.br
+}
+
+func (t *Task) publish(typ EventType) {
+	t.mu.Lock()
+	b := t.br
+	e := Event{Type: typ, Status: t.Status, Added: t.Added, Done: t.Done, Error: t.Error}
+	t.mu.Unlock()
+	if b != nil {
+		b.publish(e)
+	}
+}
+
+// Clone returns a snapshot of t safe to hand to callers (e.g. a Store or
+// an HTTP handler) without exposing t's mutex or broker to copying by
+// value.
+func (t *Task) Clone() *Task {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cp := &Task{
+		ID:           t.ID,
+		Owner:        t.Owner,
+		Format:       t.Format,
+		Started:      t.Started,
+		Status:       t.Status,
+		Error:        t.Error,
+		ResultKey:    t.ResultKey,
+		ResultURL:    t.ResultURL,
+		Added:        t.Added,
+		Done:         t.Done,
+		CreatedAt:    t.CreatedAt,
+		ExpiresAt:    t.ExpiresAt,
+		Downloads:    t.Downloads,
+		MaxDownloads: t.MaxDownloads,
+		Callback:     t.Callback,
+		br:           t.br,
+	}
+	cp.Items = make([]*Item, len(t.Items))
+	for i, it := range t.Items {
+		itc := *it
+		cp.Items[i] = &itc
+	}
+	return cp
+}
+
+func (t *Task) publishItem(typ EventType, it *Item) {
+	t.mu.Lock()
+	b := t.br
+	cp := *it
+	e := Event{Type: typ, Status: t.Status, Added: t.Added, Done: t.Done, Item: &cp}
+	t.mu.Unlock()
+	if b != nil {
+		b.publish(e)
+	}
 }
 
 type Manager struct {
-	mu      sync.RWMutex
-	tasks   map[string]*Task
-	sem     chan struct{}
-	client  *http.Client
-	tmpDir  string
-	outDir  string
-	limitMB int64
+	mu               sync.RWMutex
+	live             map[string]*Task
+	store            Store
+	backend          storage.Backend
+	sem              chan struct{}
+	client           *http.Client
+	tmpDir           string
+	outDir           string
+	limitMB          int64
+	ttl              time.Duration
+	maxDownloads     int
+	downloadWorkers  int
+	callbackAttempts int
+	stopSweep        chan struct{}
+	quotas           Quotas
+}
+
+// SetQuotas installs a per-owner quota enforcer; pass nil to disable
+// quota checks (the default).
+func (m *Manager) SetQuotas(q Quotas) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quotas = q
 }
 
 func NewManager(parallel int) *Manager {
+	var store Store
+	store, err := NewJSONFileStore(filepath.Join("state", "tasks"))
+	if err != nil {
+		store = NewMemoryStore()
+	}
+	backend, err := storage.FromEnv("results", "/files")
+	if err != nil {
+		backend = storage.NewLocal("results", "/files")
+	}
+	return NewManagerWithStore(parallel, store, backend, defaultTTL, defaultMaxDownloads)
+}
+
+// NewManagerWithStore builds a Manager against a caller-supplied Store
+// and storage Backend, so tasks can be backed by on-disk JSON, BoltDB,
+// or an in-memory map, and archives can live on the local filesystem or
+// an S3-compatible bucket, interchangeably. ttl and maxDownloads
+// configure the background sweep that reclaims expired or
+// over-downloaded archives; zero disables the corresponding check.
+func NewManagerWithStore(parallel int, store Store, backend storage.Backend, ttl time.Duration, maxDownloads int) *Manager {
 	_ = os.MkdirAll("tmp", 0o755)
 	_ = os.MkdirAll("results", 0o755)
-	return &Manager{
-		tasks:   make(map[string]*Task),
-		sem:     make(chan struct{}, parallel),
-		tmpDir:  "tmp",
-		outDir:  "results",
-		client:  &http.Client{Timeout: 15 * time.Second},
-		limitMB: 25,
+	m := &Manager{
+		live:         make(map[string]*Task),
+		backend:      backend,
+		store:        store,
+		sem:          make(chan struct{}, parallel),
+		tmpDir:       "tmp",
+		outDir:       "results",
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return nil
+			},
+		},
+		limitMB:          25,
+		ttl:              ttl,
+		maxDownloads:     maxDownloads,
+		downloadWorkers:  defaultDownloadWorkers,
+		callbackAttempts: defaultCallbackAttempts,
+		stopSweep:        make(chan struct{}),
 	}
+	m.resume()
+	go m.sweepLoop()
+	return m
 }
 
-func (m *Manager) Create() *Task {
+// resume scans the store plus the storage backend and tmp/ on startup.
+// Tasks that were still running when the process died are marked
+// errored (they can't be safely continued), tmp directories of tasks the
+// store doesn't know about are removed (a known task's tmp directory is
+// kept, so a re-run can resume its partial downloads), and any archive
+// the backend has that isn't tracked by the store is re-registered as
+// done so it isn't orphaned.
+func (m *Manager) resume() {
+	tasks, err := m.store.List()
+	if err != nil {
+		return
+	}
+	known := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		known[t.ID] = true
+		if t.Status == StatusRunning {
+			t.Status = StatusError
+			t.Error = "interrupted by restart"
+			t.Started = false
+			_ = m.store.Save(t)
+		}
+	}
+
+	keys, err := m.backend.List(context.Background())
+	if err == nil {
+		for _, key := range keys {
+			format, id := archiveIDFromFilename(key)
+			if format == "" || known[id] {
+				continue
+			}
+			now := m.now()
+			t := &Task{
+				ID:           id,
+				Format:       format,
+				Started:      true,
+				Status:       StatusDone,
+				ResultKey:    key,
+				CreatedAt:    now,
+				ExpiresAt:    now.Add(m.ttl),
+				MaxDownloads: m.maxDownloads,
+			}
+			if url, err := m.backend.URL(context.Background(), key); err == nil {
+				t.ResultURL = url
+			}
+			_ = m.store.Save(t)
+		}
+	}
+
+	if entries, err := os.ReadDir(m.tmpDir); err == nil {
+		for _, e := range entries {
+			if e.IsDir() && !known[e.Name()] {
+				_ = os.RemoveAll(filepath.Join(m.tmpDir, e.Name()))
+			}
+		}
+	}
+}
+
+// now is overridable in tests; wall-clock time otherwise.
+func (m *Manager) now() time.Time { return time.Now() }
+
+// sweepLoop periodically reclaims expired or over-downloaded archives.
+func (m *Manager) sweepLoop() {
+	interval := m.ttl / 4
+	if interval <= 0 || interval > time.Hour {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.sweep()
+		case <-m.stopSweep:
+			return
+		}
+	}
+}
+
+// Close stops the background sweeper. It does not affect in-flight runs.
+func (m *Manager) Close() {
+	close(m.stopSweep)
+}
+
+func (m *Manager) sweep() {
+	tasks, err := m.store.List()
+	if err != nil {
+		return
+	}
+	now := m.now()
+	for _, t := range tasks {
+		if t.Status != StatusDone && t.Status != StatusError {
+			continue
+		}
+		expired := m.ttl > 0 && !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt)
+		overDownloaded := t.MaxDownloads > 0 && t.Downloads >= t.MaxDownloads
+		if !expired && !overDownloaded {
+			continue
+		}
+		if t.ResultKey != "" {
+			_ = m.backend.Delete(context.Background(), t.ResultKey)
+		}
+		_ = m.store.Delete(t.ID)
+		m.mu.Lock()
+		delete(m.live, t.ID)
+		m.mu.Unlock()
+	}
+}
+
+// CreateOptions configures a new task.
+type CreateOptions struct {
+	// Owner identifies the caller (typically their bearer token) so
+	// Status/Result can be restricted to their own tasks and quotas
+	// enforced per-owner. Empty means no owner/quota enforcement.
+	Owner string
+	// Format is the archive container (zip, tar, tar.gz, tar.zst); an
+	// empty value falls back to DefaultFormat.
+	Format string
+	// CallbackURL, if set, is POSTed the task's terminal state once it
+	// finishes. CallbackSecret, if also set, signs the body via HMAC.
+	CallbackURL    string
+	CallbackSecret string
+}
+
+// Create starts a new task per opts. The concurrent-task quota isn't
+// checked here since a created-but-never-run task holds no resources;
+// it's enforced in Run instead, against the same lifecycle event that
+// releases it.
+func (m *Manager) Create(opts CreateOptions) (*Task, error) {
+	f, err := ParseFormat(opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
 	id := newID()
-	t := &Task{ID: id, Status: StatusNew}
+	now := m.now()
+	t := &Task{
+		ID:           id,
+		Owner:        opts.Owner,
+		Format:       f,
+		Status:       StatusNew,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(m.ttl),
+		MaxDownloads: m.maxDownloads,
+		Callback:     Callback{URL: opts.CallbackURL, Secret: opts.CallbackSecret},
+	}
 	m.mu.Lock()
-	m.tasks[id] = t
+	m.live[id] = t
 	m.mu.Unlock()
-	return t
+	_ = m.store.Save(t)
+	return t, nil
 }
 
 func (m *Manager) AddItem(id string, rawurl string) (added, limit int, err error) {
@@ -98,8 +423,9 @@ func (m *Manager) AddItem(id string, rawurl string) (added, limit int, err error
 	if _, err := url.ParseRequestURI(rawurl); err != nil {
 		return len(t.Items), 3, fmt.Errorf("bad url: %w", err)
 	}
-	t.Items = append(t.Items, rawurl)
+	t.Items = append(t.Items, &Item{URL: rawurl, State: ItemPending})
 	t.Added = len(t.Items)
+	_ = m.store.Save(t)
 	return t.Added, 3, nil
 }
 
@@ -121,12 +447,27 @@ func (m *Manager) Run(id string, ctx context.Context) error {
 	t.Status = StatusNew
 	t.mu.Unlock()
 
+	m.mu.RLock()
+	quotas := m.quotas
+	m.mu.RUnlock()
+	if quotas != nil && t.Owner != "" && !quotas.AllowCreate(t.Owner) {
+		t.mu.Lock()
+		t.Started = false
+		t.mu.Unlock()
+		return ErrQuotaExceeded
+	}
+	_ = m.store.Save(t)
+
 	select {
 	case m.sem <- struct{}{}:
 	default:
 		t.mu.Lock()
 		t.Started = false
 		t.mu.Unlock()
+		_ = m.store.Save(t)
+		if quotas != nil && t.Owner != "" {
+			quotas.ReleaseCreate(t.Owner)
+		}
 		return ErrBusy
 	}
 
@@ -142,42 +483,148 @@ func (m *Manager) Status(id string) *Task {
 	if t == nil {
 		return nil
 	}
+	return t.Clone()
+}
+
+// ResultURL returns a URL clients can use to fetch id's result archive
+// right now, minting a fresh one from the storage backend rather than
+// replaying the URL captured when the archive was written (which, for a
+// presigned remote backend, may have since expired independently of the
+// task's own TTL). ErrNotFound covers both an unknown task and one with
+// no result yet.
+func (m *Manager) ResultURL(id string) (string, error) {
+	t := m.get(id)
+	if t == nil {
+		return "", ErrNotFound
+	}
 	t.mu.Lock()
-	defer t.mu.Unlock()
-	out := *t
-	return &out
+	key := t.ResultKey
+	t.mu.Unlock()
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return m.backend.URL(context.Background(), key)
+}
+
+// RecordDownload counts a fetch of the task's result archive against its
+// max-downloads policy; the next sweep reclaims it once the limit or TTL
+// is reached.
+func (m *Manager) RecordDownload(id string) {
+	t := m.get(id)
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.Downloads++
+	t.mu.Unlock()
+	_ = m.store.Save(t)
 }
 
+// Subscribe returns a channel of Event for id, plus a cancel func that
+// must be called once the subscriber is done. The channel is fed the
+// most recent event immediately, if one exists, so a late subscriber
+// (e.g. reconnecting with Last-Event-ID) gets a coherent initial view.
+func (m *Manager) Subscribe(id string) (<-chan Event, func()) {
+	t := m.get(id)
+	if t == nil {
+		ch := make(chan Event)
+		close(ch)
+		return ch, func() {}
+	}
+	b := t.ensureBroker()
+	ch, last, cancel := b.subscribe()
+	if last.Seq != 0 {
+		select {
+		case ch <- last:
+		default:
+		}
+	}
+	return ch, cancel
+}
+
+// get returns the live, in-process Task for id, loading and caching it
+// from the store on first access (e.g. after a restart).
 func (m *Manager) get(id string) *Task {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.tasks[id]
+	t, ok := m.live[id]
+	m.mu.RUnlock()
+	if ok {
+		return t
+	}
+
+	loaded, err := m.store.Load(id)
+	if err != nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.live[id]; ok {
+		return t
+	}
+	m.live[id] = loaded
+	return loaded
 }
 
 func (m *Manager) processTask(t *Task) {
+	if t.Callback.URL != "" {
+		defer func() { go m.deliverCallback(t) }()
+	}
+	if t.Owner != "" {
+		m.mu.RLock()
+		quotas := m.quotas
+		m.mu.RUnlock()
+		if quotas != nil {
+			defer quotas.ReleaseCreate(t.Owner)
+		}
+	}
+
 	t.mu.Lock()
 	t.Status = StatusRunning
 	t.Error = ""
 	t.Done = 0
 	t.mu.Unlock()
+	_ = m.store.Save(t)
+	t.publish(EventStateChanged)
 
 	tmpTaskDir := filepath.Join(m.tmpDir, t.ID)
 	_ = os.MkdirAll(tmpTaskDir, 0o755)
 	defer os.RemoveAll(tmpTaskDir)
 
+	paths := make([]string, len(t.Items))
+	var doneCount int32
+
+	eg := &errgroup.Group{}
+	eg.SetLimit(m.downloadWorkers)
+	for i, it := range t.Items {
+		i, it := i, it
+		eg.Go(func() error {
+			fn, err := m.download(t, it, tmpTaskDir)
+			t.mu.Lock()
+			if err != nil {
+				it.State = ItemError
+				it.Error = err.Error()
+			} else {
+				it.State = ItemDone
+				paths[i] = fn
+			}
+			doneCount++
+			t.Done = int(doneCount)
+			t.mu.Unlock()
+			_ = m.store.Save(t)
+			t.publishItem(EventItemDone, it)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
 	var downloaded []string
 	var errs []string
-
-	for _, u := range t.Items {
-		fn, err := m.download(u, tmpTaskDir)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", u, err))
+	for i, it := range t.Items {
+		if paths[i] != "" {
+			downloaded = append(downloaded, paths[i])
 		} else {
-			downloaded = append(downloaded, fn)
+			errs = append(errs, fmt.Sprintf("%s: %s", it.URL, it.Error))
 		}
-		t.mu.Lock()
-		t.Done = len(downloaded)
-		t.mu.Unlock()
 	}
 
 	if len(downloaded) == 0 {
@@ -185,20 +632,71 @@ func (m *Manager) processTask(t *Task) {
 		t.Status = StatusError
 		t.Error = "all downloads failed"
 		t.mu.Unlock()
+		_ = m.store.Save(t)
+		t.publish(EventStateChanged)
+		return
+	}
+
+	archiver, err := NewArchiver(t.Format)
+	if err != nil {
+		t.mu.Lock()
+		t.Status = StatusError
+		t.Error = err.Error()
+		t.mu.Unlock()
+		_ = m.store.Save(t)
+		t.publish(EventStateChanged)
 		return
 	}
 
-	out := filepath.Join(m.outDir, t.ID+".zip")
-	if err := zipFiles(out, downloaded); err != nil {
+	staged := filepath.Join(tmpTaskDir, "archive"+t.Format.Ext())
+	if err := archiver.Archive(staged, downloaded); err != nil {
 		t.mu.Lock()
 		t.Status = StatusError
-		t.Error = "zip error: " + err.Error()
+		t.Error = "archive error: " + err.Error()
 		t.mu.Unlock()
+		_ = m.store.Save(t)
+		t.publish(EventStateChanged)
+		return
+	}
+	t.publish(EventArchiveBuilt)
+
+	if t.Owner != "" {
+		m.mu.RLock()
+		quotas := m.quotas
+		m.mu.RUnlock()
+		if quotas != nil {
+			size := int64(0)
+			if fi, statErr := os.Stat(staged); statErr == nil {
+				size = fi.Size()
+			}
+			if !quotas.AllowArchive(t.Owner, size) {
+				t.mu.Lock()
+				t.Status = StatusError
+				t.Error = ErrQuotaExceeded.Error()
+				t.mu.Unlock()
+				_ = m.store.Save(t)
+				t.publish(EventStateChanged)
+				return
+			}
+		}
+	}
+
+	key := t.ID + t.Format.Ext()
+	resultURL, err := m.putResult(key, staged)
+	if err != nil {
+		t.mu.Lock()
+		t.Status = StatusError
+		t.Error = "storage error: " + err.Error()
+		t.mu.Unlock()
+		_ = m.store.Save(t)
+		t.publish(EventStateChanged)
 		return
 	}
 
 	t.mu.Lock()
-	t.ResultPath = out
+	t.ResultKey = key
+	t.ResultURL = resultURL
+	t.ExpiresAt = m.now().Add(m.ttl)
 	if len(errs) > 0 {
 		t.Status = StatusDone
 		t.Error = strings.Join(errs, "; ")
@@ -206,87 +704,165 @@ func (m *Manager) processTask(t *Task) {
 		t.Status = StatusDone
 	}
 	t.mu.Unlock()
+	_ = m.store.Save(t)
+	t.publish(EventStateChanged)
 }
 
-func (m *Manager) download(u, dir string) (string, error) {
-	req, err := http.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return "", err
-	}
-	resp, err := m.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("status %d", resp.StatusCode)
-	}
+// allowedContentTypes mirrors isAllowed's URL-suffix check but is applied
+// to the actual bytes received, defending against mislabeled URLs.
+var allowedContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+}
 
-	limit := m.limitMB * 1024 * 1024
-	r := io.LimitReader(resp.Body, limit+1)
-	data, err := io.ReadAll(r)
+// putResult uploads the staged archive at path to the active storage
+// backend under key and returns the URL clients should use to fetch it.
+func (m *Manager) putResult(key, path string) (string, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return "", err
 	}
-	if int64(len(data)) > limit {
-		return "", fmt.Errorf("file too large (> %d MB)", m.limitMB)
-	}
-
-	ct := strings.ToLower(resp.Header.Get("Content-Type"))
-	_ = ct
+	defer f.Close()
+	return m.backend.Put(context.Background(), key, f)
+}
 
-	filename := safeName(filepath.Base(u))
+func (m *Manager) download(t *Task, it *Item, dir string) (string, error) {
+	filename := safeName(filepath.Base(it.URL))
 	if filename == "" {
 		filename = "file"
 	}
-	if strings.HasSuffix(strings.ToLower(u), ".pdf") && !strings.HasSuffix(strings.ToLower(filename), ".pdf") {
+	lu := strings.ToLower(it.URL)
+	if strings.HasSuffix(lu, ".pdf") && !strings.HasSuffix(strings.ToLower(filename), ".pdf") {
 		filename += ".pdf"
 	}
-	if strings.HasSuffix(strings.ToLower(u), ".jpeg") && !strings.HasSuffix(strings.ToLower(filename), ".jpeg") {
+	if strings.HasSuffix(lu, ".jpeg") && !strings.HasSuffix(strings.ToLower(filename), ".jpeg") {
 		filename += ".jpeg"
 	}
 	path := filepath.Join(dir, filename)
-	if err := os.WriteFile(path, data, 0o644); err != nil {
-		return "", err
+
+	var lastErr error
+	for attempt := 0; attempt < maxDownloadAttempts; attempt++ {
+		if err := m.downloadAttempt(t, it, path); err != nil {
+			lastErr = err
+			continue
+		}
+		return path, nil
 	}
-	return path, nil
+	return "", lastErr
 }
 
-func zipFiles(out string, files []string) error {
-	tmp := out + ".tmp"
-	f, err := os.Create(tmp)
+// downloadAttempt streams one HTTP response directly into a temp file,
+// sending a Range request to resume a prior partial attempt, enforcing
+// limitMB against both Content-Length and the actual bytes read, and
+// sniffing the real Content-Type against allowedContentTypes.
+func (m *Manager) downloadAttempt(t *Task, it *Item, path string) error {
+	resumeFrom := int64(0)
+	if fi, err := os.Stat(path); err == nil {
+		resumeFrom = fi.Size()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, it.URL, nil)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	zw := zip.NewWriter(f)
-	for _, p := range files {
-		if err := addFile(zw, p); err != nil {
-			_ = zw.Close()
-			return err
-		}
+	if resumeFrom > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", resumeFrom))
 	}
-	if err := zw.Close(); err != nil {
+	resp, err := m.client.Do(req)
+	if err != nil {
 		return err
 	}
-	_ = os.Remove(out)
-	return os.Rename(tmp, out)
-}
+	defer resp.Body.Close()
 
-func addFile(zw *zip.Writer, path string) error {
-	w, err := zw.Create(filepath.Base(path))
-	if err != nil {
-		return err
+	switch resp.StatusCode {
+	case http.StatusOK:
+		resumeFrom = 0
+	case http.StatusPartialContent:
+		// server honored our Range request; keep resumeFrom as-is.
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil // a prior attempt already fetched the whole file
+	default:
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	limit := m.limitMB * 1024 * 1024
+	if resp.ContentLength >= 0 && resumeFrom+resp.ContentLength > limit {
+		return fmt.Errorf("file too large (> %d MB)", m.limitMB)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if resumeFrom > 0 && resp.StatusCode == http.StatusPartialContent {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+		resumeFrom = 0
 	}
-	fd, err := os.Open(path)
+	f, err := os.OpenFile(path, flags, 0o644)
 	if err != nil {
 		return err
 	}
-	defer fd.Close()
-	_, err = io.Copy(w, fd)
-	return err
+	defer f.Close()
+
+	t.mu.Lock()
+	it.State = ItemDownloading
+	it.BytesDownloaded = resumeFrom
+	if resp.ContentLength >= 0 {
+		it.BytesTotal = resumeFrom + resp.ContentLength
+	}
+	t.mu.Unlock()
+	t.publishItem(EventItemStarted, it)
+
+	// Only sniff the magic-number prefix on a fresh download: a resumed
+	// request's first chunk is mid-file, not the file's actual prefix,
+	// so sniffing it here would reject legitimate resumes. A retried
+	// attempt can still hit a server returning a different (mislabeled)
+	// resource, though, so fall back to checking the declared
+	// Content-Type header even when we can't sniff the body.
+	sniffed := resumeFrom > 0
+	if sniffed {
+		if ct := resp.Header.Get("Content-Type"); ct != "" && !validContentType(ct) {
+			return fmt.Errorf("unexpected content type %q", ct)
+		}
+	}
+	written := resumeFrom
+	buf := make([]byte, 32*1024)
+	for {
+		n, rerr := resp.Body.Read(buf)
+		if n > 0 {
+			if !sniffed {
+				sniffed = true
+				ct := http.DetectContentType(buf[:n])
+				if !validContentType(ct) && !validContentType(resp.Header.Get("Content-Type")) {
+					return fmt.Errorf("unexpected content type %q", ct)
+				}
+			}
+			written += int64(n)
+			if written > limit {
+				return fmt.Errorf("file too large (> %d MB)", m.limitMB)
+			}
+			if _, werr := f.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			t.mu.Lock()
+			it.BytesDownloaded = written
+			t.mu.Unlock()
+			t.publishItem(EventItemProgress, it)
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+}
+
+func validContentType(ct string) bool {
+	ct = strings.ToLower(strings.TrimSpace(ct))
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	return allowedContentTypes[ct]
 }
 
 func isAllowed(u string) bool {
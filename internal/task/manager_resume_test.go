@@ -0,0 +1,159 @@
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LiHiy93/workmate-zipper/internal/storage"
+)
+
+// TestDownloadAttemptSkipsSniffOnResume guards against content-type
+// sniffing running on a resumed (206) response's first chunk, which is
+// mid-file rather than the file's magic-number prefix.
+func TestDownloadAttemptSkipsSniffOnResume(t *testing.T) {
+	content := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("x"), 5000)...)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader := r.Header.Get("Range")
+		if rangeHeader == "" {
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(content)
+			return
+		}
+		var start int
+		_, _ = fmt.Sscanf(rangeHeader, "bytes=%d-", &start)
+		// A real server reports the full resource's Content-Type on a
+		// 206 response, not one sniffed from the requested byte range.
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(content[start:])
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.pdf")
+	// Pre-seed the file with the first few bytes, simulating a prior
+	// attempt that already wrote the magic-number prefix to disk.
+	if err := os.WriteFile(path, content[:20], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{client: http.DefaultClient, limitMB: 25}
+	tk := &Task{}
+	it := &Item{URL: ts.URL}
+
+	if err := m.downloadAttempt(tk, it, path); err != nil {
+		t.Fatalf("downloadAttempt: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("file content mismatch after resumed download")
+	}
+}
+
+// TestDownloadAttemptValidatesContentTypeOnResume guards against a
+// retried, Range-resumed attempt skipping Content-Type validation
+// entirely just because body-sniffing can't run on a mid-file chunk: a
+// server returning a mismatched Content-Type header on resume must
+// still be rejected.
+func TestDownloadAttemptValidatesContentTypeOnResume(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 5000)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader := r.Header.Get("Range")
+		var start int
+		_, _ = fmt.Sscanf(rangeHeader, "bytes=%d-", &start)
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(content[start:])
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.pdf")
+	if err := os.WriteFile(path, content[:20], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{client: http.DefaultClient, limitMB: 25}
+	tk := &Task{}
+	it := &Item{URL: ts.URL}
+
+	err := m.downloadAttempt(tk, it, path)
+	if err == nil {
+		t.Fatal("downloadAttempt should reject a resumed response with a disallowed Content-Type")
+	}
+}
+
+// TestResumeScansStoreAndBackend covers the three things resume is
+// documented to do on startup: a task still StatusRunning when the
+// process died is marked StatusError, an archive the backend has that
+// the store doesn't know about is re-registered as StatusDone, and a
+// stale tmp/ directory left by an untracked task is removed.
+func TestResumeScansStoreAndBackend(t *testing.T) {
+	store := NewMemoryStore()
+	running := &Task{ID: "running-task", Status: StatusRunning, Started: true}
+	if err := store.Save(running); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	backendDir := t.TempDir()
+	backend := storage.NewLocal(backendDir, "/files")
+	const orphanKey = "orphan-task.zip"
+	if err := os.WriteFile(filepath.Join(backendDir, orphanKey), []byte("zip data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	staleDir := filepath.Join(tmpDir, "stale-task")
+	if err := os.MkdirAll(staleDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		live:    make(map[string]*Task),
+		store:   store,
+		backend: backend,
+		tmpDir:  tmpDir,
+		ttl:     time.Hour,
+	}
+	m.resume()
+
+	got, err := store.Load("running-task")
+	if err != nil {
+		t.Fatalf("Load(running-task): %v", err)
+	}
+	if got.Status != StatusError {
+		t.Errorf("interrupted task Status = %q, want %q", got.Status, StatusError)
+	}
+	if got.Started {
+		t.Error("interrupted task Started should be cleared")
+	}
+
+	orphan, err := store.Load("orphan-task")
+	if err != nil {
+		t.Fatalf("Load(orphan-task): %v", err)
+	}
+	if orphan.Status != StatusDone {
+		t.Errorf("orphan archive Status = %q, want %q", orphan.Status, StatusDone)
+	}
+	if orphan.ResultKey != orphanKey {
+		t.Errorf("orphan archive ResultKey = %q, want %q", orphan.ResultKey, orphanKey)
+	}
+
+	if _, err := os.Stat(staleDir); !os.IsNotExist(err) {
+		t.Error("resume should have removed the stale tmp dir for an untracked task")
+	}
+}
@@ -0,0 +1,153 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store persists task state so that tasks survive process restarts.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Save(t *Task) error
+	Load(id string) (*Task, error)
+	List() ([]*Task, error)
+	Delete(id string) error
+}
+
+// MemoryStore keeps tasks in memory only; all state is lost on restart.
+// It is the default for tests and for callers that don't need durability.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	tasks map[string]*Task
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tasks: make(map[string]*Task)}
+}
+
+func (s *MemoryStore) Save(t *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[t.ID] = t.Clone()
+	return nil
+}
+
+func (s *MemoryStore) Load(id string) (*Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return t.Clone(), nil
+}
+
+func (s *MemoryStore) List() ([]*Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		out = append(out, t.Clone())
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tasks, id)
+	return nil
+}
+
+// JSONFileStore persists each task as its own JSON file under dir, so
+// tasks survive process restarts: the startup scan picks them back up
+// and the background sweep later reclaims expired ones.
+type JSONFileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func NewJSONFileStore(dir string) (*JSONFileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &JSONFileStore{dir: dir}, nil
+}
+
+func (s *JSONFileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *JSONFileStore) Save(t *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Clone before marshaling: t's fields are mutated under t.mu by
+	// other goroutines (see manager.go's processTask), and json.Marshal
+	// reading them unlocked would race.
+	data, err := json.Marshal(t.Clone())
+	if err != nil {
+		return err
+	}
+	tmp := s.path(t.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(t.ID))
+}
+
+func (s *JSONFileStore) Load(id string) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked(id)
+}
+
+func (s *JSONFileStore) loadLocked(id string) (*Task, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Task
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *JSONFileStore) List() ([]*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Task, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), ".json")
+		t, err := s.loadLocked(id)
+		if err != nil {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (s *JSONFileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
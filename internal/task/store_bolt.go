@@ -0,0 +1,90 @@
+package task
+
+import (
+	"encoding/json"
+
+	"go.etcd.io/bbolt"
+)
+
+// boltBucket holds one key per task ID, value being its JSON encoding.
+var boltBucket = []byte("tasks")
+
+// BoltStore persists tasks in a single BoltDB file, an alternative to
+// JSONFileStore for deployments that want one durable file instead of
+// one-file-per-task.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Close releases the underlying BoltDB file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) Save(t *Task) error {
+	// Clone before marshaling: t's fields are mutated under t.mu by
+	// other goroutines (see manager.go's processTask), and json.Marshal
+	// reading them unlocked would race.
+	data, err := json.Marshal(t.Clone())
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(t.ID), data)
+	})
+}
+
+func (s *BoltStore) Load(id string) (*Task, error) {
+	var t Task
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *BoltStore) List() ([]*Task, error) {
+	var out []*Task
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			var t Task
+			if err := json.Unmarshal(v, &t); err != nil {
+				return nil // skip a corrupt entry rather than failing the whole scan
+			}
+			out = append(out, &t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) Delete(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(id))
+	})
+}
@@ -0,0 +1,175 @@
+package task
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestMemoryStoreRoundTrip(t *testing.T) {
+	s := NewMemoryStore()
+	orig := &Task{ID: "abc", Owner: "tok", Status: StatusNew, Items: []*Item{{URL: "http://x/a.pdf", State: ItemPending}}}
+
+	if err := s.Save(orig); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Mutating the caller's copy after Save must not affect the stored
+	// snapshot: Save takes its own clone rather than aliasing orig.
+	orig.Status = StatusRunning
+	orig.Items[0].State = ItemDone
+
+	loaded, err := s.Load("abc")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Status != StatusNew {
+		t.Errorf("Status = %q, want %q (Save should have cloned)", loaded.Status, StatusNew)
+	}
+	if loaded.Items[0].State != ItemPending {
+		t.Errorf("Items[0].State = %q, want %q (Save should have cloned items)", loaded.Items[0].State, ItemPending)
+	}
+
+	all, err := s.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != "abc" {
+		t.Fatalf("List() = %+v, want one task with ID abc", all)
+	}
+
+	if err := s.Delete("abc"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Load("abc"); err != ErrNotFound {
+		t.Errorf("Load after Delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestJSONFileStoreRoundTrip(t *testing.T) {
+	s, err := NewJSONFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONFileStore: %v", err)
+	}
+	orig := &Task{ID: "abc", Owner: "tok", Status: StatusNew, Items: []*Item{{URL: "http://x/a.pdf", State: ItemPending}}}
+
+	if err := s.Save(orig); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Mutating the caller's copy after Save must not affect the saved
+	// file: Save should clone before marshaling rather than aliasing orig.
+	orig.Status = StatusRunning
+	orig.Items[0].State = ItemDone
+
+	loaded, err := s.Load("abc")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Status != StatusNew {
+		t.Errorf("Status = %q, want %q (Save should have cloned)", loaded.Status, StatusNew)
+	}
+	if loaded.Items[0].State != ItemPending {
+		t.Errorf("Items[0].State = %q, want %q (Save should have cloned items)", loaded.Items[0].State, ItemPending)
+	}
+
+	if err := s.Delete("abc"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Load("abc"); err != ErrNotFound {
+		t.Errorf("Load after Delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBoltStoreRoundTrip(t *testing.T) {
+	s, err := NewBoltStore(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	defer s.Close()
+	orig := &Task{ID: "abc", Owner: "tok", Status: StatusNew, Items: []*Item{{URL: "http://x/a.pdf", State: ItemPending}}}
+
+	if err := s.Save(orig); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	orig.Status = StatusRunning
+	orig.Items[0].State = ItemDone
+
+	loaded, err := s.Load("abc")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Status != StatusNew {
+		t.Errorf("Status = %q, want %q (Save should have cloned)", loaded.Status, StatusNew)
+	}
+	if loaded.Items[0].State != ItemPending {
+		t.Errorf("Items[0].State = %q, want %q (Save should have cloned items)", loaded.Items[0].State, ItemPending)
+	}
+
+	if err := s.Delete("abc"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Load("abc"); err != ErrNotFound {
+		t.Errorf("Load after Delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestStoreSaveDoesNotRaceWithItemMutation mirrors the concurrency pattern
+// in processTask: one goroutine mutates a Task's items under t.mu while
+// another repeatedly calls Save. Run with -race; it only catches the bug
+// Save marshaling t directly (instead of t.Clone()) used to trigger.
+func TestStoreSaveDoesNotRaceWithItemMutation(t *testing.T) {
+	stores := map[string]Store{}
+	if s, err := NewJSONFileStore(t.TempDir()); err != nil {
+		t.Fatalf("NewJSONFileStore: %v", err)
+	} else {
+		stores["JSONFileStore"] = s
+	}
+	if s, err := NewBoltStore(filepath.Join(t.TempDir(), "tasks.db")); err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	} else {
+		defer s.Close()
+		stores["BoltStore"] = s
+	}
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			tk := &Task{ID: "race", Items: []*Item{{URL: "http://x/a.pdf"}, {URL: "http://x/b.pdf"}}}
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					tk.mu.Lock()
+					tk.Items[0].BytesDownloaded = int64(i)
+					tk.Items[1].State = ItemDone
+					tk.mu.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					_ = s.Save(tk)
+				}
+			}()
+			wg.Wait()
+		})
+	}
+}
+
+func TestTaskCloneIndependentOfSource(t *testing.T) {
+	orig := &Task{ID: "x", Items: []*Item{{URL: "http://x/a.pdf", State: ItemPending}}}
+	clone := orig.Clone()
+
+	clone.Status = StatusDone
+	clone.Items[0].State = ItemDone
+
+	if orig.Status == StatusDone {
+		t.Error("mutating clone.Status affected orig")
+	}
+	if orig.Items[0].State == ItemDone {
+		t.Error("mutating clone.Items affected orig.Items")
+	}
+}
@@ -0,0 +1,123 @@
+package task
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// defaultCallbackAttempts bounds webhook delivery retries before giving up.
+const defaultCallbackAttempts = 5
+
+// callbackBackoffBase is the delay before the first retry; it doubles
+// after each subsequent failed attempt.
+const callbackBackoffBase = 500 * time.Millisecond
+
+// DeliveryAttempt records the outcome of one webhook POST.
+type DeliveryAttempt struct {
+	At         time.Time
+	StatusCode int
+	Error      string
+}
+
+// Callback is a caller-registered webhook fired once a task reaches a
+// terminal state.
+type Callback struct {
+	URL       string
+	Secret    string
+	Delivered bool
+	Attempts  []DeliveryAttempt
+}
+
+type callbackPayload struct {
+	ID        string `json:"id"`
+	Status    Status `json:"status"`
+	ResultURL string `json:"result_url"`
+	Error     string `json:"error"`
+	Done      int    `json:"done"`
+	Added     int    `json:"added"`
+}
+
+func (m *Manager) callbackPayload(t *Task) callbackPayload {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := callbackPayload{ID: t.ID, Status: t.Status, Error: t.Error, Done: t.Done, Added: t.Added}
+	p.ResultURL = t.ResultURL
+	return p
+}
+
+// deliverCallback POSTs the task's terminal state to its registered
+// callback URL, retrying with exponential backoff and recording every
+// attempt on the task so it's visible via Status.
+func (m *Manager) deliverCallback(t *Task) {
+	t.mu.Lock()
+	url := t.Callback.URL
+	secret := t.Callback.Secret
+	t.mu.Unlock()
+	if url == "" {
+		return
+	}
+
+	body, err := json.Marshal(m.callbackPayload(t))
+	if err != nil {
+		return
+	}
+
+	delay := callbackBackoffBase
+	attempts := m.callbackAttempts
+	if attempts <= 0 {
+		attempts = defaultCallbackAttempts
+	}
+	for attempt := 0; attempt < attempts; attempt++ {
+		statusCode, postErr := m.postCallback(url, secret, body)
+		delivered := postErr == nil && statusCode >= 200 && statusCode < 300
+
+		t.mu.Lock()
+		t.Callback.Attempts = append(t.Callback.Attempts, DeliveryAttempt{
+			At:         m.now(),
+			StatusCode: statusCode,
+			Error:      errString(postErr),
+		})
+		t.Callback.Delivered = delivered
+		t.mu.Unlock()
+		_ = m.store.Save(t)
+
+		if delivered {
+			return
+		}
+		if attempt < attempts-1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+func (m *Manager) postCallback(rawurl, secret string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, rawurl, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
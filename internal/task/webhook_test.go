@@ -0,0 +1,62 @@
+package task
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostCallbackSignsBody(t *testing.T) {
+	var gotSig string
+	var gotBody []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-Signature")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	m := &Manager{client: http.DefaultClient}
+	body := []byte(`{"id":"abc"}`)
+	status, err := m.postCallback(ts.URL, "s3cr3t", body)
+	if err != nil {
+		t.Fatalf("postCallback: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if gotSig != want {
+		t.Errorf("X-Signature = %q, want %q", gotSig, want)
+	}
+	if !bytes.Equal(gotBody, body) {
+		t.Errorf("posted body = %q, want %q", gotBody, body)
+	}
+}
+
+func TestDeliverCallbackMarksDelivered(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	m := &Manager{client: http.DefaultClient, store: NewMemoryStore(), callbackAttempts: 1}
+	tk := &Task{ID: "t1", Status: StatusDone, Callback: Callback{URL: ts.URL, Secret: "s3cr3t"}}
+
+	m.deliverCallback(tk)
+
+	if !tk.Callback.Delivered {
+		t.Error("Callback.Delivered = false, want true")
+	}
+	if len(tk.Callback.Attempts) != 1 {
+		t.Errorf("len(Attempts) = %d, want 1", len(tk.Callback.Attempts))
+	}
+}